@@ -0,0 +1,175 @@
+package fitbit
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"golang.org/x/oauth2"
+)
+
+// validUserID bounds the characters allowed in a user ID before it is
+// used to build a file path, so a caller can't pass something like
+// "../../../etc/passwd" through to the filesystem.
+var validUserID = regexp.MustCompile(`^[A-Za-z0-9_-]{1,128}$`)
+
+// ValidUserID reports whether userID is safe to use as a TokenStore key
+// (and, by extension, anywhere else a Fitbit user ID is taken from an
+// unauthenticated request parameter).
+func ValidUserID(userID string) bool {
+	return validUserID.MatchString(userID)
+}
+
+// TokenStore persists one OAuth2 token per user, encrypted at rest with
+// AES-GCM under the key in FITBIT_TOKEN_KEY (32 bytes, base64-encoded).
+// Keeping tokens per user rather than in a single global variable lets
+// one server back multiple Fitbit accounts.
+type TokenStore struct {
+	dir string
+	key []byte
+	mu  sync.Mutex
+}
+
+// NewTokenStore returns a TokenStore that writes encrypted token files
+// under dir, creating it if necessary.
+func NewTokenStore(dir string) (*TokenStore, error) {
+	keyB64 := os.Getenv("FITBIT_TOKEN_KEY")
+	if keyB64 == "" {
+		return nil, fmt.Errorf("fitbit: FITBIT_TOKEN_KEY is not set")
+	}
+	key, err := base64.StdEncoding.DecodeString(keyB64)
+	if err != nil || len(key) != 32 {
+		return nil, fmt.Errorf("fitbit: FITBIT_TOKEN_KEY must be 32 bytes, base64-encoded")
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("fitbit: create token store dir: %w", err)
+	}
+	return &TokenStore{dir: dir, key: key}, nil
+}
+
+func (s *TokenStore) path(userID string) (string, error) {
+	if !validUserID.MatchString(userID) {
+		return "", fmt.Errorf("fitbit: invalid user id %q", userID)
+	}
+	return filepath.Join(s.dir, userID+".json.enc"), nil
+}
+
+// ListUserIDs returns the IDs of all users with a token currently on
+// disk, e.g. so the server can re-subscribe to webhooks on startup.
+func (s *TokenStore) ListUserIDs() ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("fitbit: list token store: %w", err)
+	}
+
+	var ids []string
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, ".json.enc") {
+			continue
+		}
+		ids = append(ids, strings.TrimSuffix(name, ".json.enc"))
+	}
+	return ids, nil
+}
+
+// Save encrypts and writes tok for userID, overwriting any prior token.
+func (s *TokenStore) Save(userID string, tok *oauth2.Token) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	plaintext, err := json.Marshal(tok)
+	if err != nil {
+		return fmt.Errorf("fitbit: marshal token: %w", err)
+	}
+
+	ciphertext, err := s.encrypt(plaintext)
+	if err != nil {
+		return err
+	}
+
+	path, err := s.path(userID)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, ciphertext, 0o600)
+}
+
+// Load decrypts and returns the stored token for userID, or nil if none
+// is present.
+func (s *TokenStore) Load(userID string) (*oauth2.Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	path, err := s.path(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	ciphertext, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("fitbit: read token for %s: %w", userID, err)
+	}
+
+	plaintext, err := s.decrypt(ciphertext)
+	if err != nil {
+		return nil, err
+	}
+
+	var tok oauth2.Token
+	if err := json.Unmarshal(plaintext, &tok); err != nil {
+		return nil, fmt.Errorf("fitbit: unmarshal token for %s: %w", userID, err)
+	}
+	return &tok, nil
+}
+
+func (s *TokenStore) encrypt(plaintext []byte) ([]byte, error) {
+	block, err := aes.NewCipher(s.key)
+	if err != nil {
+		return nil, fmt.Errorf("fitbit: new cipher: %w", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("fitbit: new gcm: %w", err)
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("fitbit: generate nonce: %w", err)
+	}
+	return gcm.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func (s *TokenStore) decrypt(ciphertext []byte) ([]byte, error) {
+	block, err := aes.NewCipher(s.key)
+	if err != nil {
+		return nil, fmt.Errorf("fitbit: new cipher: %w", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("fitbit: new gcm: %w", err)
+	}
+	if len(ciphertext) < gcm.NonceSize() {
+		return nil, fmt.Errorf("fitbit: ciphertext too short")
+	}
+	nonce, sealed := ciphertext[:gcm.NonceSize()], ciphertext[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, sealed, nil)
+	if err != nil {
+		return nil, fmt.Errorf("fitbit: decrypt token: %w", err)
+	}
+	return plaintext, nil
+}
@@ -0,0 +1,51 @@
+package fitbit
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// SubscriptionManager creates and removes Fitbit subscriptions so a
+// deployment can self-register for webhook notifications on startup.
+type SubscriptionManager struct {
+	client *Client
+}
+
+// NewSubscriptionManager returns a SubscriptionManager that issues
+// subscription requests authenticated as the user behind client.
+func NewSubscriptionManager(client *Client) *SubscriptionManager {
+	return &SubscriptionManager{client: client}
+}
+
+// Create subscribes to notifications for collection (e.g. "activities"),
+// identified by subscriptionID.
+func (m *SubscriptionManager) Create(collection, subscriptionID string) error {
+	url := fmt.Sprintf("https://api.fitbit.com/1/user/-/%s/apiSubscriptions/%s.json", collection, subscriptionID)
+	return m.do(http.MethodPost, url)
+}
+
+// Delete removes a previously created subscription.
+func (m *SubscriptionManager) Delete(collection, subscriptionID string) error {
+	url := fmt.Sprintf("https://api.fitbit.com/1/user/-/%s/apiSubscriptions/%s.json", collection, subscriptionID)
+	return m.do(http.MethodDelete, url)
+}
+
+func (m *SubscriptionManager) do(method, url string) error {
+	req, err := http.NewRequest(method, url, nil)
+	if err != nil {
+		return fmt.Errorf("fitbit: build subscription request: %w", err)
+	}
+
+	resp, err := m.client.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("fitbit: subscription request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("fitbit: subscription request %s %s: status %d: %s", method, url, resp.StatusCode, body)
+	}
+	return nil
+}
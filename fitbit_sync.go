@@ -0,0 +1,207 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prkpwm/fitness-tracker-backend/fitbit"
+)
+
+const fitbitTokenDir = "fitbit_tokens"
+
+var (
+	fitbitStore     *fitbit.TokenStore
+	fitbitStoreOnce sync.Once
+	fitbitStoreErr  error
+	fitbitPendingMu sync.Mutex
+	fitbitPending   = map[string]string{} // state -> userID
+)
+
+func getFitbitStore() (*fitbit.TokenStore, error) {
+	fitbitStoreOnce.Do(func() {
+		fitbitStore, fitbitStoreErr = fitbit.NewTokenStore(fitbitTokenDir)
+	})
+	return fitbitStore, fitbitStoreErr
+}
+
+// fitbitClientForUser loads userID's stored token and returns a Client
+// authenticated as them.
+func fitbitClientForUser(ctx context.Context, userID string) (*fitbit.Client, error) {
+	store, err := getFitbitStore()
+	if err != nil {
+		return nil, fmt.Errorf("Fitbit integration is not configured: %w", err)
+	}
+
+	token, err := store.Load(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load Fitbit token for user %s: %w", userID, err)
+	}
+	if token == nil {
+		return nil, fmt.Errorf("user %s has not linked Fitbit", userID)
+	}
+
+	cfg := fitbit.NewConfig()
+	return fitbit.NewClient(ctx, cfg, token), nil
+}
+
+// fitbitLoginHandler redirects the user to Fitbit's consent screen,
+// stashing a CSRF state token that ties the callback back to userID.
+func fitbitLoginHandler(w http.ResponseWriter, r *http.Request) {
+	userID := r.URL.Query().Get("user_id")
+	if !fitbit.ValidUserID(userID) {
+		http.Error(w, "user_id is required and must match [A-Za-z0-9_-]+", http.StatusBadRequest)
+		return
+	}
+
+	state, err := fitbit.NewState()
+	if err != nil {
+		log.Printf("Fitbit login: failed to generate state: %v", err)
+		http.Error(w, "failed to start Fitbit login", http.StatusInternalServerError)
+		return
+	}
+
+	fitbitPendingMu.Lock()
+	fitbitPending[state] = userID
+	fitbitPendingMu.Unlock()
+
+	cfg := fitbit.NewConfig()
+	http.Redirect(w, r, fitbit.AuthURL(cfg, state), http.StatusFound)
+}
+
+// fitbitGrantHandler handles the OAuth2 callback, verifies state, and
+// persists the resulting token for the associated user.
+func fitbitGrantHandler(w http.ResponseWriter, r *http.Request) {
+	state := r.URL.Query().Get("state")
+	code := r.URL.Query().Get("code")
+	if state == "" || code == "" {
+		http.Error(w, "missing state or code", http.StatusBadRequest)
+		return
+	}
+
+	fitbitPendingMu.Lock()
+	userID, ok := fitbitPending[state]
+	if ok {
+		delete(fitbitPending, state)
+	}
+	fitbitPendingMu.Unlock()
+
+	if !ok {
+		http.Error(w, "unknown or expired state", http.StatusBadRequest)
+		return
+	}
+
+	cfg := fitbit.NewConfig()
+	token, err := fitbit.ParseToken(r.Context(), cfg, code)
+	if err != nil {
+		log.Printf("Fitbit grant: token exchange failed for user %s: %v", userID, err)
+		http.Error(w, "failed to exchange Fitbit code", http.StatusBadGateway)
+		return
+	}
+
+	store, err := getFitbitStore()
+	if err != nil {
+		log.Printf("Fitbit grant: token store unavailable: %v", err)
+		http.Error(w, "Fitbit integration is not configured", http.StatusInternalServerError)
+		return
+	}
+
+	if err := store.Save(userID, token); err != nil {
+		log.Printf("Fitbit grant: failed to save token for user %s: %v", userID, err)
+		http.Error(w, "failed to save Fitbit token", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "linked", "user_id": userID})
+}
+
+// fitbitSyncHandler pulls the day's activity summary for a user and
+// merges it into that day's FitnessData record.
+func fitbitSyncHandler(w http.ResponseWriter, r *http.Request) {
+	userID := r.URL.Query().Get("user_id")
+	if !fitbit.ValidUserID(userID) {
+		http.Error(w, "user_id is required and must match [A-Za-z0-9_-]+", http.StatusBadRequest)
+		return
+	}
+
+	date := r.URL.Query().Get("date")
+	if date == "" {
+		date = time.Now().Format("2006-01-02")
+	}
+	if _, err := time.Parse("2006-01-02", date); err != nil {
+		http.Error(w, "Invalid date format", http.StatusBadRequest)
+		return
+	}
+
+	client, err := fitbitClientForUser(r.Context(), userID)
+	if err != nil {
+		log.Printf("Fitbit sync: failed to load client for user %s: %v", userID, err)
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	daily, err := client.DailyActivitySummary(date)
+	if err != nil {
+		log.Printf("Fitbit sync: failed to fetch activity summary for user %s on %s: %v", userID, date, err)
+		http.Error(w, "failed to fetch Fitbit activity summary", http.StatusBadGateway)
+		return
+	}
+
+	record := mergeFitbitActivity(userID, date, daily)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(record)
+}
+
+// fitbitSource is the ExerciseEntry/BurnedCaloriesBySource key used for
+// one Fitbit user's contributions, so two linked accounts syncing the
+// same date merge instead of overwriting each other.
+func fitbitSource(userID string) string {
+	return "fitbit:" + userID
+}
+
+// mergeFitbitActivity maps a Fitbit daily activity response into the
+// ExerciseSummary of date's FitnessData record, preserving any existing
+// FoodDiary/UserProfile as well as any other contributor's workouts for
+// that day, then persists the merged record.
+func mergeFitbitActivity(userID, date string, daily *fitbit.DailyActivity) FitnessData {
+	source := fitbitSource(userID)
+
+	record := mutateFitnessRecord(date, func(record FitnessData) FitnessData {
+		remaining := record.ExerciseSummary.Workouts[:0]
+		for _, w := range record.ExerciseSummary.Workouts {
+			if w.Source != source {
+				remaining = append(remaining, w)
+			}
+		}
+		record.ExerciseSummary.Workouts = remaining
+		for _, a := range daily.Activities {
+			record.ExerciseSummary.Workouts = append(record.ExerciseSummary.Workouts, ExerciseEntry{
+				Name:     a.Name,
+				Calories: a.Calories,
+				Minutes:  a.Duration,
+				Source:   source,
+			})
+		}
+
+		if record.ExerciseSummary.BurnedCaloriesBySource == nil {
+			record.ExerciseSummary.BurnedCaloriesBySource = map[string]int{}
+		}
+		record.ExerciseSummary.BurnedCaloriesBySource[source] = daily.Summary.CaloriesOut + daily.Summary.ActivityCalories
+		total := 0
+		for _, calories := range record.ExerciseSummary.BurnedCaloriesBySource {
+			total += calories
+		}
+		record.ExerciseSummary.TotalBurnedCalories = total
+
+		return record
+	})
+
+	saveData()
+	return record
+}
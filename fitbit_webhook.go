@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/prkpwm/fitness-tracker-backend/fitbit"
+)
+
+type fitbitNotification struct {
+	CollectionType string `json:"collectionType"`
+	OwnerID        string `json:"ownerId"`
+	Date           string `json:"date"`
+	SubscriptionID string `json:"subscriptionId"`
+}
+
+// fitbitWebhookVerifyHandler answers Fitbit's subscriber verification
+// handshake: https://dev.fitbit.com/build/reference/web-api/subscription/.
+func fitbitWebhookVerifyHandler(w http.ResponseWriter, r *http.Request) {
+	want := os.Getenv("FITBIT_VERIFY_CODE")
+	got := r.URL.Query().Get("verify")
+	if want == "" || got != want {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// fitbitWebhookHandler verifies the X-Fitbit-Signature on incoming
+// subscription notifications and kicks off a background sync for each.
+func fitbitWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "failed to read body", http.StatusBadRequest)
+		return
+	}
+
+	if !verifyFitbitSignature(body, r.Header.Get("X-Fitbit-Signature")) {
+		log.Printf("Fitbit webhook: signature verification failed")
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+
+	var notifications []fitbitNotification
+	if err := json.Unmarshal(body, &notifications); err != nil {
+		log.Printf("Fitbit webhook: failed to parse notifications: %v", err)
+		http.Error(w, "invalid notification payload", http.StatusBadRequest)
+		return
+	}
+
+	for _, n := range notifications {
+		n := n
+		log.Printf("Fitbit webhook: notification for owner %s, collection %s, date %s (subscription %s)",
+			n.OwnerID, n.CollectionType, n.Date, n.SubscriptionID)
+		go syncFitbitNotification(n)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// verifyFitbitSignature checks the X-Fitbit-Signature header against
+// hmac_sha1(clientSecret + "&", body), base64-encoded, in constant time.
+func verifyFitbitSignature(body []byte, signatureHeader string) bool {
+	if signatureHeader == "" {
+		return false
+	}
+
+	secret := os.Getenv("FITBIT_CLIENT_SECRET")
+	mac := hmac.New(sha1.New, []byte(secret+"&"))
+	mac.Write(body)
+	computed := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+	return hmac.Equal([]byte(computed), []byte(signatureHeader))
+}
+
+// syncFitbitNotification re-fetches the affected day for a notification's
+// owner and persists it through saveData(). The Fitbit-assigned owner ID
+// is expected to double as the user_id used when the account was linked
+// via /auth/fitbit/login.
+func syncFitbitNotification(n fitbitNotification) {
+	client, err := fitbitClientForUser(context.Background(), n.OwnerID)
+	if err != nil {
+		log.Printf("Fitbit webhook: no client for owner %s: %v", n.OwnerID, err)
+		return
+	}
+
+	daily, err := client.DailyActivitySummary(n.Date)
+	if err != nil {
+		log.Printf("Fitbit webhook: failed to fetch activity for owner %s on %s: %v", n.OwnerID, n.Date, err)
+		return
+	}
+
+	mergeFitbitActivity(n.OwnerID, n.Date, daily)
+}
+
+// registerFitbitSubscriptions creates an "activities" subscription for
+// every linked user so the deployment self-registers on startup.
+func registerFitbitSubscriptions() {
+	store, err := getFitbitStore()
+	if err != nil {
+		log.Printf("Fitbit subscriptions: token store unavailable: %v", err)
+		return
+	}
+
+	userIDs, err := store.ListUserIDs()
+	if err != nil {
+		log.Printf("Fitbit subscriptions: failed to list linked users: %v", err)
+		return
+	}
+
+	for _, userID := range userIDs {
+		client, err := fitbitClientForUser(context.Background(), userID)
+		if err != nil {
+			log.Printf("Fitbit subscriptions: skipping user %s: %v", userID, err)
+			continue
+		}
+
+		mgr := fitbit.NewSubscriptionManager(client)
+		if err := mgr.Create("activities", userID); err != nil {
+			log.Printf("Fitbit subscriptions: failed to subscribe user %s: %v", userID, err)
+			continue
+		}
+		log.Printf("Fitbit subscriptions: subscribed user %s to activities notifications", userID)
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/prkpwm/fitness-tracker-backend/fitbit"
+)
+
+// TestMergeFitbitActivityConcurrentSyncs exercises the scenario a single
+// Fitbit webhook POST can trigger: several notifications for the same
+// date, each handled on its own goroutine (see fitbit_webhook.go's
+// `go syncFitbitNotification(n)`). Run with `go test -race`, this would
+// fail instantly on a concurrent map write / slice mutation if
+// mergeFitbitActivity's accesses to fitnessRecords weren't serialized
+// through fitnessMu.
+func TestMergeFitbitActivityConcurrentSyncs(t *testing.T) {
+	setFitnessRecords(nil)
+
+	const n = 20
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		i := i
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			daily := &fitbit.DailyActivity{
+				Summary: fitbit.ActivitySummary{CaloriesOut: 100 + i},
+				Activities: []fitbit.Activity{
+					{Name: "run", Calories: 100 + i, Duration: 30},
+				},
+			}
+			mergeFitbitActivity(fmt.Sprintf("user-%d", i), "2026-07-29", daily)
+		}()
+	}
+	wg.Wait()
+
+	records := snapshotFitnessRecords()
+	if len(records) != 1 {
+		t.Fatalf("expected exactly one record for the shared date, got %d", len(records))
+	}
+	if got := len(records[0].ExerciseSummary.BurnedCaloriesBySource); got != n {
+		t.Fatalf("expected %d distinct per-user totals, got %d", n, got)
+	}
+}
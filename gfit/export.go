@@ -0,0 +1,211 @@
+package gfit
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/api/fitness/v1"
+)
+
+// NutritionEntry is one food diary entry to push as a
+// com.google.nutrition data point.
+type NutritionEntry struct {
+	At       time.Time
+	Calories float64
+	ProteinG float64
+	CarbsG   float64
+	FatG     float64
+}
+
+// CalorieEntry is a point-in-time calories-expended reading.
+type CalorieEntry struct {
+	At       time.Time
+	Calories float64
+}
+
+// ActivitySegment is one logged workout to push as a
+// com.google.activity.segment data point.
+type ActivitySegment struct {
+	Start time.Time
+	End   time.Time
+	// ActivityType follows Google Fit's activity type enum; 8 is
+	// "running" and is used as a generic fallback for unmapped workouts.
+	ActivityType int64
+}
+
+// WeightEntry is a single body-weight reading.
+type WeightEntry struct {
+	At        time.Time
+	Kilograms float64
+}
+
+// PushNutrition upserts entries into the caller's com.google.nutrition
+// stream named streamName, creating the backing data source on first use.
+func (c *Client) PushNutrition(ctx context.Context, streamName string, entries []NutritionEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	ds, err := c.ensureDataSource(ctx, nutritionDataSource(streamName))
+	if err != nil {
+		return err
+	}
+
+	points := make([]*fitness.DataPoint, 0, len(entries))
+	for _, e := range entries {
+		ns := e.At.UnixNano()
+		points = append(points, &fitness.DataPoint{
+			DataTypeName:   ds.DataType.Name,
+			StartTimeNanos: ns,
+			EndTimeNanos:   ns,
+			Value: []*fitness.Value{{
+				MapVal: []*fitness.ValueMapValEntry{
+					{Key: "calories", Value: &fitness.MapValue{FpVal: e.Calories}},
+					{Key: "protein", Value: &fitness.MapValue{FpVal: e.ProteinG}},
+					{Key: "carbs.total", Value: &fitness.MapValue{FpVal: e.CarbsG}},
+					{Key: "fat.total", Value: &fitness.MapValue{FpVal: e.FatG}},
+				},
+			}},
+		})
+	}
+
+	return c.patchDataset(ctx, ds.DataStreamId, buildDataset(points))
+}
+
+// PushCaloriesExpended upserts entries into the caller's
+// com.google.calories.expended stream named streamName.
+func (c *Client) PushCaloriesExpended(ctx context.Context, streamName string, entries []CalorieEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	ds, err := c.ensureDataSource(ctx, caloriesExpendedDataSource(streamName))
+	if err != nil {
+		return err
+	}
+
+	points := make([]*fitness.DataPoint, 0, len(entries))
+	for _, e := range entries {
+		ns := e.At.UnixNano()
+		points = append(points, &fitness.DataPoint{
+			DataTypeName:   ds.DataType.Name,
+			StartTimeNanos: ns,
+			EndTimeNanos:   ns,
+			Value:          []*fitness.Value{{FpVal: e.Calories}},
+		})
+	}
+
+	return c.patchDataset(ctx, ds.DataStreamId, buildDataset(points))
+}
+
+// PushActivitySegments upserts segments into the caller's
+// com.google.activity.segment stream named streamName.
+func (c *Client) PushActivitySegments(ctx context.Context, streamName string, segments []ActivitySegment) error {
+	if len(segments) == 0 {
+		return nil
+	}
+
+	ds, err := c.ensureDataSource(ctx, activitySegmentDataSource(streamName))
+	if err != nil {
+		return err
+	}
+
+	points := make([]*fitness.DataPoint, 0, len(segments))
+	for _, s := range segments {
+		points = append(points, &fitness.DataPoint{
+			DataTypeName:   ds.DataType.Name,
+			StartTimeNanos: s.Start.UnixNano(),
+			EndTimeNanos:   s.End.UnixNano(),
+			Value:          []*fitness.Value{{IntVal: s.ActivityType}},
+		})
+	}
+
+	return c.patchDataset(ctx, ds.DataStreamId, buildDataset(points))
+}
+
+// PushWeight upserts entries into the caller's com.google.weight stream
+// named streamName.
+func (c *Client) PushWeight(ctx context.Context, streamName string, entries []WeightEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	ds, err := c.ensureDataSource(ctx, weightDataSource(streamName))
+	if err != nil {
+		return err
+	}
+
+	points := make([]*fitness.DataPoint, 0, len(entries))
+	for _, e := range entries {
+		ns := e.At.UnixNano()
+		points = append(points, &fitness.DataPoint{
+			DataTypeName:   ds.DataType.Name,
+			StartTimeNanos: ns,
+			EndTimeNanos:   ns,
+			Value:          []*fitness.Value{{FpVal: e.Kilograms}},
+		})
+	}
+
+	return c.patchDataset(ctx, ds.DataStreamId, buildDataset(points))
+}
+
+func nutritionDataSource(streamName string) *fitness.DataSource {
+	dataType := &fitness.DataType{Name: "com.google.nutrition"}
+	return &fitness.DataSource{
+		Type:           "raw",
+		DataStreamId:   DataStreamID("raw", dataType.Name, packageName, deviceUID, streamName),
+		DataStreamName: streamName,
+		DataType:       dataType,
+		Application:    &fitness.Application{PackageName: packageName},
+	}
+}
+
+func caloriesExpendedDataSource(streamName string) *fitness.DataSource {
+	dataType := &fitness.DataType{Name: "com.google.calories.expended"}
+	return &fitness.DataSource{
+		Type:           "raw",
+		DataStreamId:   DataStreamID("raw", dataType.Name, packageName, deviceUID, streamName),
+		DataStreamName: streamName,
+		DataType:       dataType,
+		Application:    &fitness.Application{PackageName: packageName},
+	}
+}
+
+func activitySegmentDataSource(streamName string) *fitness.DataSource {
+	dataType := &fitness.DataType{Name: "com.google.activity.segment"}
+	return &fitness.DataSource{
+		Type:           "raw",
+		DataStreamId:   DataStreamID("raw", dataType.Name, packageName, deviceUID, streamName),
+		DataStreamName: streamName,
+		DataType:       dataType,
+		Application:    &fitness.Application{PackageName: packageName},
+	}
+}
+
+func weightDataSource(streamName string) *fitness.DataSource {
+	dataType := &fitness.DataType{Name: "com.google.weight"}
+	return &fitness.DataSource{
+		Type:           "raw",
+		DataStreamId:   DataStreamID("raw", dataType.Name, packageName, deviceUID, streamName),
+		DataStreamName: streamName,
+		DataType:       dataType,
+		Application:    &fitness.Application{PackageName: packageName},
+	}
+}
+
+func buildDataset(points []*fitness.DataPoint) *fitness.Dataset {
+	minNs, maxNs := points[0].StartTimeNanos, points[0].EndTimeNanos
+	for _, p := range points[1:] {
+		if p.StartTimeNanos < minNs {
+			minNs = p.StartTimeNanos
+		}
+		if p.EndTimeNanos > maxNs {
+			maxNs = p.EndTimeNanos
+		}
+	}
+	return &fitness.Dataset{
+		MinStartTimeNs: minNs,
+		MaxEndTimeNs:   maxNs,
+		Point:          points,
+	}
+}
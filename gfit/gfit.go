@@ -0,0 +1,134 @@
+// Package gfit mirrors saved FitnessData into Google Fit so a user's
+// data also shows up in the Google Fit app, complementing the Fitbit
+// import direction.
+package gfit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+	"google.golang.org/api/fitness/v1"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/option"
+
+	"github.com/prkpwm/fitness-tracker-backend/retry"
+)
+
+// packageName and deviceUID identify this backend as the data source's
+// owning app/device when building deterministic DataStreamIDs.
+const (
+	packageName = "com.prkpwm.fitnesstracker"
+	deviceUID   = "fitness-tracker-backend"
+)
+
+// NewConfig builds an oauth2.Config requesting write access to activity,
+// body and nutrition data, from the GFIT_CLIENT_ID/GFIT_CLIENT_SECRET
+// environment variables.
+func NewConfig() *oauth2.Config {
+	return &oauth2.Config{
+		ClientID:     os.Getenv("GFIT_CLIENT_ID"),
+		ClientSecret: os.Getenv("GFIT_CLIENT_SECRET"),
+		Endpoint:     google.Endpoint,
+		Scopes: []string{
+			fitness.FitnessActivityWriteScope,
+			fitness.FitnessBodyWriteScope,
+			fitness.FitnessNutritionWriteScope,
+		},
+	}
+}
+
+// Client wraps the Google Fit REST API for a single authorized account.
+type Client struct {
+	svc *fitness.Service
+}
+
+// NewClient builds a Client authorized with the refresh token in
+// GFIT_REFRESH_TOKEN.
+func NewClient(ctx context.Context) (*Client, error) {
+	refreshToken := os.Getenv("GFIT_REFRESH_TOKEN")
+	if refreshToken == "" {
+		return nil, fmt.Errorf("gfit: GFIT_REFRESH_TOKEN is not set")
+	}
+
+	cfg := NewConfig()
+	httpClient := cfg.Client(ctx, &oauth2.Token{RefreshToken: refreshToken})
+
+	svc, err := fitness.NewService(ctx, option.WithHTTPClient(httpClient))
+	if err != nil {
+		return nil, fmt.Errorf("gfit: create service: %w", err)
+	}
+	return &Client{svc: svc}, nil
+}
+
+// DataStreamID builds a deterministic data stream id from typ (Google
+// Fit's "raw" or "derived"), dataTypeName (e.g. "com.google.weight"),
+// packageName, device and streamName, so re-syncing the same logical
+// stream always resolves to the same data source instead of creating
+// duplicates.
+func DataStreamID(typ, dataTypeName, packageName, device, streamName string) string {
+	return fmt.Sprintf("%s:%s:%s:%s:%s", typ, dataTypeName, packageName, device, streamName)
+}
+
+// ensureDataSource creates ds if it doesn't exist yet, or fetches the
+// existing one on a 409 conflict so callers always get back a data
+// source with a valid DataStreamId.
+func (c *Client) ensureDataSource(ctx context.Context, ds *fitness.DataSource) (*fitness.DataSource, error) {
+	var result *fitness.DataSource
+	err := retry.Do(ctx, func(ctx context.Context) error {
+		created, err := c.svc.Users.DataSources.Create("me", ds).Context(ctx).Do()
+		if err == nil {
+			result = created
+			return nil
+		}
+
+		if !isConflict(err) {
+			return classifyGoogleError(err)
+		}
+
+		existing, getErr := c.svc.Users.DataSources.Get("me", ds.DataStreamId).Context(ctx).Do()
+		if getErr != nil {
+			return classifyGoogleError(getErr)
+		}
+		result = existing
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("gfit: ensure data source %s: %w", ds.DataStreamId, err)
+	}
+	return result, nil
+}
+
+// patchDataset upserts points into dataSourceID for the span they cover.
+func (c *Client) patchDataset(ctx context.Context, dataSourceID string, dataset *fitness.Dataset) error {
+	datasetID := fmt.Sprintf("%d-%d", dataset.MinStartTimeNs, dataset.MaxEndTimeNs)
+	err := retry.Do(ctx, func(ctx context.Context) error {
+		_, err := c.svc.Users.DataSources.Datasets.Patch("me", dataSourceID, datasetID, dataset).Context(ctx).Do()
+		if err != nil {
+			return classifyGoogleError(err)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("gfit: patch dataset %s: %w", dataSourceID, err)
+	}
+	return nil
+}
+
+func isConflict(err error) bool {
+	var gerr *googleapi.Error
+	return errors.As(err, &gerr) && gerr.Code == 409
+}
+
+// classifyGoogleError marks 4xx googleapi errors as permanent so
+// retry.Do doesn't keep retrying a request that will never succeed.
+func classifyGoogleError(err error) error {
+	var gerr *googleapi.Error
+	if errors.As(err, &gerr) && gerr.Code >= 400 && gerr.Code < 500 {
+		return retry.Abort(err)
+	}
+	return err
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/prkpwm/fitness-tracker-backend/gfit"
+)
+
+// googleFitActivityTypeGeneric is Google Fit's "running" activity type,
+// used as a catch-all since Fitbit/manual workout entries don't carry a
+// Google Fit activity type of their own.
+const googleFitActivityTypeGeneric = 8
+
+// exportToGoogleFit mirrors a just-saved FitnessData record into Google
+// Fit. It's meant to run in its own goroutine after saveData() succeeds;
+// a failure here never blocks or fails the originating request.
+func exportToGoogleFit(record FitnessData) {
+	ctx := context.Background()
+
+	client, err := gfit.NewClient(ctx)
+	if err != nil {
+		log.Printf("Google Fit export: client unavailable: %v", err)
+		return
+	}
+
+	day, err := time.Parse("2006-01-02", record.Date)
+	if err != nil {
+		log.Printf("Google Fit export: invalid date %s: %v", record.Date, err)
+		return
+	}
+
+	if err := client.PushNutrition(ctx, "food_diary", nutritionEntries(day, record.FoodDiary)); err != nil {
+		log.Printf("Google Fit export: nutrition for %s: %v", record.Date, err)
+	}
+
+	if record.ExerciseSummary.TotalBurnedCalories > 0 {
+		calories := []gfit.CalorieEntry{{
+			At:       day.Add(23*time.Hour + 59*time.Minute),
+			Calories: float64(record.ExerciseSummary.TotalBurnedCalories),
+		}}
+		if err := client.PushCaloriesExpended(ctx, "exercise_summary", calories); err != nil {
+			log.Printf("Google Fit export: calories for %s: %v", record.Date, err)
+		}
+	}
+
+	if err := client.PushActivitySegments(ctx, "workouts", activitySegments(day, record.ExerciseSummary.Workouts)); err != nil {
+		log.Printf("Google Fit export: activity segments for %s: %v", record.Date, err)
+	}
+
+	if record.UserProfile.WeightKg > 0 {
+		weight := []gfit.WeightEntry{{At: day, Kilograms: record.UserProfile.WeightKg}}
+		if err := client.PushWeight(ctx, "user_profile", weight); err != nil {
+			log.Printf("Google Fit export: weight for %s: %v", record.Date, err)
+		}
+	}
+}
+
+func nutritionEntries(day time.Time, items []FoodItem) []gfit.NutritionEntry {
+	entries := make([]gfit.NutritionEntry, 0, len(items))
+	for _, item := range items {
+		entries = append(entries, gfit.NutritionEntry{
+			At:       timeOnDay(day, item.Time),
+			Calories: float64(item.Calories),
+			ProteinG: item.ProteinG,
+			CarbsG:   item.CarbsG,
+			FatG:     item.FatG,
+		})
+	}
+	return entries
+}
+
+func activitySegments(day time.Time, workouts []ExerciseEntry) []gfit.ActivitySegment {
+	segments := make([]gfit.ActivitySegment, 0, len(workouts))
+	for _, w := range workouts {
+		start := day
+		segments = append(segments, gfit.ActivitySegment{
+			Start:        start,
+			End:          start.Add(time.Duration(w.Minutes) * time.Minute),
+			ActivityType: googleFitActivityTypeGeneric,
+		})
+	}
+	return segments
+}
+
+// timeOnDay combines day with a "15:04"-formatted clock time, falling
+// back to midnight if clock can't be parsed.
+func timeOnDay(day time.Time, clock string) time.Time {
+	t, err := time.Parse("15:04", clock)
+	if err != nil {
+		return day
+	}
+	return time.Date(day.Year(), day.Month(), day.Day(), t.Hour(), t.Minute(), 0, 0, day.Location())
+}
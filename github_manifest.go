@@ -0,0 +1,49 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+const manifestFile = "manifest.json"
+
+// syncManifest records, per cached file, the blob SHA it was downloaded
+// at, plus the commit SHA it was synced from. On the next sync, files
+// whose blob SHA hasn't changed are skipped.
+type syncManifest struct {
+	CommitSHA string            `json:"commit_sha"`
+	SyncedAt  string            `json:"synced_at"`
+	Files     map[string]string `json:"files"` // repo path -> blob sha
+}
+
+func loadSyncManifest() (*syncManifest, error) {
+	data, err := os.ReadFile(filepath.Join(dataDir, manifestFile))
+	if os.IsNotExist(err) {
+		return &syncManifest{Files: map[string]string{}}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read manifest: %w", err)
+	}
+
+	var m syncManifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("parse manifest: %w", err)
+	}
+	if m.Files == nil {
+		m.Files = map[string]string{}
+	}
+	return &m, nil
+}
+
+func (m *syncManifest) save() error {
+	if err := os.MkdirAll(dataDir, 0o755); err != nil {
+		return fmt.Errorf("create data dir: %w", err)
+	}
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal manifest: %w", err)
+	}
+	return os.WriteFile(filepath.Join(dataDir, manifestFile), data, 0o644)
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/prkpwm/fitness-tracker-backend/retry"
+)
+
+// errGitHubConflict signals a 409 on a contents PUT: the cached SHA is
+// stale and the caller should refetch it before retrying.
+var errGitHubConflict = errors.New("github: conflict, sha is stale")
+
+// wrapGitHubError classifies a GitHub API response for retry.Do: 4xx
+// responses are permanent (retry.Abort) except a rate-limited 403, which
+// should be retried once the limit window passes, and a 409 conflict,
+// which the caller handles by refetching the file SHA. 5xx and network
+// errors are returned as-is so retry.Do retries them.
+func wrapGitHubError(resp *http.Response, body []byte) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+
+	if resp.StatusCode == http.StatusConflict {
+		return errGitHubConflict
+	}
+
+	if resp.StatusCode == http.StatusForbidden && isGitHubRateLimited(resp) {
+		return fmt.Errorf("github: rate limited: %s", body)
+	}
+
+	err := fmt.Errorf("github: status %d: %s", resp.StatusCode, body)
+	if resp.StatusCode >= 400 && resp.StatusCode < 500 {
+		return retry.Abort(err)
+	}
+	return err
+}
+
+func isGitHubRateLimited(resp *http.Response) bool {
+	if resp.Header.Get("Retry-After") != "" {
+		return true
+	}
+	return resp.Header.Get("X-RateLimit-Remaining") == "0"
+}
+
+func readGitHubBody(resp *http.Response) []byte {
+	body, _ := io.ReadAll(resp.Body)
+	return body
+}
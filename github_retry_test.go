@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/prkpwm/fitness-tracker-backend/retry"
+)
+
+// doRequest runs one retry.Do attempt against url, classifying the
+// response the same way the production GitHub calls do.
+func doRequest(ctx context.Context, method, url string) error {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return wrapGitHubError(resp, readGitHubBody(resp))
+}
+
+func TestRetryRecoversFromServerError(t *testing.T) {
+	var attempts int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	err := retry.Do(context.Background(), func(ctx context.Context) error {
+		return doRequest(ctx, http.MethodGet, srv.URL)
+	})
+	if err != nil {
+		t.Fatalf("expected retry to recover from a transient 500, got: %v", err)
+	}
+	if got := atomic.LoadInt32(&attempts); got != 2 {
+		t.Fatalf("expected exactly 2 attempts, got %d", got)
+	}
+}
+
+func TestRetryRefetchesSHAOnConflict(t *testing.T) {
+	var attempts int32
+	var sha string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			w.WriteHeader(http.StatusConflict)
+			w.Write([]byte(`{"message":"sha mismatch"}`))
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	err := retry.Do(context.Background(), func(ctx context.Context) error {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodPut, srv.URL, nil)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+
+		body := readGitHubBody(resp)
+		if resp.StatusCode == http.StatusConflict {
+			sha = "refetched-sha"
+			return errGitHubConflict
+		}
+		return wrapGitHubError(resp, body)
+	})
+	if err != nil {
+		t.Fatalf("expected retry to recover after refetching sha on conflict, got: %v", err)
+	}
+	if sha != "refetched-sha" {
+		t.Fatalf("expected sha to be refetched after a 409, got %q", sha)
+	}
+	if got := atomic.LoadInt32(&attempts); got != 2 {
+		t.Fatalf("expected exactly 2 attempts, got %d", got)
+	}
+}
+
+func TestRetryRetriesRateLimitedForbidden(t *testing.T) {
+	var attempts int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"message":"rate limited"}`))
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	err := retry.Do(context.Background(), func(ctx context.Context) error {
+		return doRequest(ctx, http.MethodGet, srv.URL)
+	})
+	if err != nil {
+		t.Fatalf("expected a rate-limited 403 to be retried, got: %v", err)
+	}
+	if got := atomic.LoadInt32(&attempts); got != 2 {
+		t.Fatalf("expected exactly 2 attempts, got %d", got)
+	}
+}
+
+func TestRetryAbortsOnPlainForbidden(t *testing.T) {
+	var attempts int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"message":"forbidden"}`))
+	}))
+	defer srv.Close()
+
+	err := retry.Do(context.Background(), func(ctx context.Context) error {
+		return doRequest(ctx, http.MethodGet, srv.URL)
+	})
+	if err == nil {
+		t.Fatal("expected a non-rate-limited 403 to fail permanently")
+	}
+	if got := atomic.LoadInt32(&attempts); got != 1 {
+		t.Fatalf("expected exactly 1 attempt (no retry), got %d", got)
+	}
+}
@@ -0,0 +1,273 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prkpwm/fitness-tracker-backend/retry"
+)
+
+// fitnessDataPrefix is the subtree of the repo that loadFromGitHub
+// mirrors into dataDir.
+const fitnessDataPrefix = dataDir + "/"
+
+const githubSyncWorkers = 16
+
+// safeFitnessDataPath cleans a GitHub tree entry's path and verifies it
+// still resolves under dataDir, the same way fitbit.ValidUserID guards
+// the token store path in the fitbit package. Without this, a tree entry
+// like "fitness_data/../../../etc/cron.d/x" would satisfy a plain
+// HasPrefix(entry.Path, fitnessDataPrefix) check and let downloadGitHubBlobs
+// write outside dataDir.
+func safeFitnessDataPath(entryPath string) (string, bool) {
+	cleaned := filepath.Clean(entryPath)
+	if !strings.HasPrefix(cleaned, fitnessDataPrefix) {
+		return "", false
+	}
+	return cleaned, true
+}
+
+// SyncStatus reports on the most recent call to refreshFromGitHub, for
+// GET /api/admin/status.
+type SyncStatus struct {
+	LastSyncTime       string `json:"last_sync_time"`
+	CachedRecordCount  int    `json:"cached_record_count"`
+	RateLimitRemaining string `json:"rate_limit_remaining"`
+}
+
+var (
+	syncStatusMu sync.Mutex
+	syncStatus   SyncStatus
+)
+
+func currentSyncStatus() SyncStatus {
+	syncStatusMu.Lock()
+	defer syncStatusMu.Unlock()
+	return syncStatus
+}
+
+func setSyncStatus(recordCount int) {
+	syncStatusMu.Lock()
+	defer syncStatusMu.Unlock()
+	syncStatus = SyncStatus{
+		LastSyncTime:       time.Now().Format(time.RFC3339),
+		CachedRecordCount:  recordCount,
+		RateLimitRemaining: lastGitHubRateLimitRemaining,
+	}
+}
+
+// refreshFromGitHub brings the on-disk fitness_data cache up to date with
+// the repo's default branch and reloads fitnessRecords from it. When the
+// head commit hasn't moved since the last sync and force is false, it
+// just reloads from the existing cache. Otherwise it fetches the whole
+// tree in one call, downloads changed day-files through a bounded pool
+// of githubSyncWorkers workers, and writes an updated manifest.
+func refreshFromGitHub(force bool) error {
+	start := time.Now()
+	token := os.Getenv("UP_TOK")
+	if token == "" {
+		log.Printf("[%s] No GitHub token available", time.Now().Format("15:04:05"))
+		return nil
+	}
+
+	headSHA, err := getGitHubHeadSHA(token, "main")
+	if err != nil {
+		return fmt.Errorf("resolve head commit: %w", err)
+	}
+
+	manifest, err := loadSyncManifest()
+	if err != nil {
+		log.Printf("[%s] Could not load manifest, starting fresh: %v", time.Now().Format("15:04:05"), err)
+		manifest = &syncManifest{Files: map[string]string{}}
+	}
+
+	if !force && manifest.CommitSHA == headSHA {
+		log.Printf("[%s] Cache already up to date with %s", time.Now().Format("15:04:05"), headSHA)
+		records, err := loadCachedRecords()
+		if err != nil {
+			return fmt.Errorf("load cached records: %w", err)
+		}
+		setFitnessRecords(records)
+		setSyncStatus(len(records))
+		return nil
+	}
+
+	tree, err := getGitHubTree(token, headSHA)
+	if err != nil {
+		return fmt.Errorf("fetch tree %s: %w", headSHA, err)
+	}
+
+	newFiles := make(map[string]string)
+	var toDownload []gitHubTreeEntry
+	for _, entry := range tree.Tree {
+		if entry.Type != "blob" || !strings.HasSuffix(entry.Path, ".json") {
+			continue
+		}
+		cleanPath, ok := safeFitnessDataPath(entry.Path)
+		if !ok {
+			log.Printf("[%s] Skipping tree entry with unsafe path %q", time.Now().Format("15:04:05"), entry.Path)
+			continue
+		}
+		entry.Path = cleanPath
+		newFiles[entry.Path] = entry.SHA
+		if manifest.Files[entry.Path] != entry.SHA {
+			toDownload = append(toDownload, entry)
+		}
+	}
+	log.Printf("[%s] %d fitness data files in tree, %d changed since last sync", time.Now().Format("15:04:05"), len(newFiles), len(toDownload))
+
+	if errs := downloadGitHubBlobs(toDownload); len(errs) > 0 {
+		log.Printf("[%s] %d of %d downloads failed, keeping their prior cached versions", time.Now().Format("15:04:05"), len(errs), len(toDownload))
+	}
+
+	manifest.CommitSHA = headSHA
+	manifest.SyncedAt = time.Now().Format(time.RFC3339)
+	manifest.Files = newFiles
+	if err := manifest.save(); err != nil {
+		log.Printf("[%s] Failed to save manifest: %v", time.Now().Format("15:04:05"), err)
+	}
+
+	records, err := loadCachedRecords()
+	if err != nil {
+		return fmt.Errorf("load cached records: %w", err)
+	}
+	setFitnessRecords(records)
+	setSyncStatus(len(records))
+
+	log.Printf("[%s] Completed GitHub sync: %d total records (took %v)", time.Now().Format("15:04:05"), len(records), time.Since(start))
+	return nil
+}
+
+// downloadGitHubBlobs fetches each entry's raw content through a pool of
+// githubSyncWorkers workers and writes it to its mirrored path under
+// dataDir, returning any per-file errors encountered.
+func downloadGitHubBlobs(entries []gitHubTreeEntry) []error {
+	sem := make(chan struct{}, githubSyncWorkers)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var errs []error
+
+	for _, entry := range entries {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(entry gitHubTreeEntry) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			data, err := downloadGitHubBlob(entry.Path)
+			if err != nil {
+				mu.Lock()
+				errs = append(errs, fmt.Errorf("%s: %w", entry.Path, err))
+				mu.Unlock()
+				return
+			}
+
+			if err := os.MkdirAll(filepath.Dir(entry.Path), 0o755); err != nil {
+				mu.Lock()
+				errs = append(errs, fmt.Errorf("%s: %w", entry.Path, err))
+				mu.Unlock()
+				return
+			}
+			if err := os.WriteFile(entry.Path, data, 0o644); err != nil {
+				mu.Lock()
+				errs = append(errs, fmt.Errorf("%s: %w", entry.Path, err))
+				mu.Unlock()
+			}
+		}(entry)
+	}
+
+	wg.Wait()
+	return errs
+}
+
+// downloadGitHubBlob fetches one file's raw content from the default
+// branch, retrying transient failures.
+func downloadGitHubBlob(githubPath string) ([]byte, error) {
+	url := fmt.Sprintf("https://raw.githubusercontent.com/prkpwm/fitness-tracker-backend/main/%s", githubPath)
+
+	var data []byte
+	err := retry.Do(context.Background(), func(ctx context.Context) error {
+		req, _ := http.NewRequestWithContext(ctx, "GET", url, nil)
+
+		client := &http.Client{}
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			return wrapGitHubError(resp, readGitHubBody(resp))
+		}
+
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return err
+		}
+		data = body
+		return nil
+	})
+	return data, err
+}
+
+// loadCachedRecords reads every cached day-file under dataDir and
+// aggregates them into the in-memory record set.
+func loadCachedRecords() ([]FitnessData, error) {
+	var records []FitnessData
+
+	err := filepath.WalkDir(dataDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.HasSuffix(path, ".json") || d.Name() == manifestFile {
+			return nil
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			log.Printf("Error reading cached file %s: %v", path, err)
+			return nil
+		}
+
+		var dayRecords []FitnessData
+		if err := json.Unmarshal(data, &dayRecords); err != nil {
+			log.Printf("Error parsing cached file %s: %v", path, err)
+			return nil
+		}
+		records = append(records, dayRecords...)
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	return records, nil
+}
+
+// adminRefreshHandler forces a full resync from GitHub, ignoring the
+// cached commit SHA.
+func adminRefreshHandler(w http.ResponseWriter, r *http.Request) {
+	if err := refreshFromGitHub(true); err != nil {
+		log.Printf("Admin refresh failed: %v", err)
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(currentSyncStatus())
+}
+
+// adminStatusHandler reports the last sync time, cached record count,
+// and GitHub rate-limit headroom.
+func adminStatusHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(currentSyncStatus())
+}
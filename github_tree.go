@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/prkpwm/fitness-tracker-backend/retry"
+)
+
+// gitHubTreeEntry mirrors one entry of the GitHub Git Trees API response.
+type gitHubTreeEntry struct {
+	Path string `json:"path"`
+	Type string `json:"type"`
+	SHA  string `json:"sha"`
+}
+
+type gitHubTree struct {
+	SHA       string            `json:"sha"`
+	Tree      []gitHubTreeEntry `json:"tree"`
+	Truncated bool              `json:"truncated"`
+}
+
+// getGitHubHeadSHA returns the commit SHA that ref (e.g. "main") currently
+// points at.
+func getGitHubHeadSHA(token, ref string) (string, error) {
+	url := fmt.Sprintf("https://api.github.com/repos/prkpwm/fitness-tracker-backend/git/refs/heads/%s", ref)
+
+	var sha string
+	err := retry.Do(context.Background(), func(ctx context.Context) error {
+		req, _ := http.NewRequestWithContext(ctx, "GET", url, nil)
+		req.Header.Set("Authorization", "token "+token)
+
+		client := &http.Client{}
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			return wrapGitHubError(resp, readGitHubBody(resp))
+		}
+
+		var result struct {
+			Object struct {
+				SHA string `json:"sha"`
+			} `json:"object"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+			return err
+		}
+		sha = result.Object.SHA
+		return nil
+	})
+	return sha, err
+}
+
+// getGitHubTree fetches the whole repo tree at ref in one call, recursing
+// into subdirectories, so the fitness_data directory doesn't need to be
+// walked one `contents/` request per directory.
+func getGitHubTree(token, ref string) (*gitHubTree, error) {
+	url := fmt.Sprintf("https://api.github.com/repos/prkpwm/fitness-tracker-backend/git/trees/%s?recursive=1", ref)
+
+	var tree gitHubTree
+	err := retry.Do(context.Background(), func(ctx context.Context) error {
+		req, _ := http.NewRequestWithContext(ctx, "GET", url, nil)
+		req.Header.Set("Authorization", "token "+token)
+
+		client := &http.Client{}
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+
+		lastGitHubRateLimitRemaining = resp.Header.Get("X-RateLimit-Remaining")
+
+		if resp.StatusCode != http.StatusOK {
+			return wrapGitHubError(resp, readGitHubBody(resp))
+		}
+		return json.NewDecoder(resp.Body).Decode(&tree)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if tree.Truncated {
+		return nil, fmt.Errorf("github: tree for %s was truncated, repo is too large to sync in one call", ref)
+	}
+	return &tree, nil
+}
+
+// lastGitHubRateLimitRemaining is the most recently observed
+// X-RateLimit-Remaining header, surfaced through GET /api/admin/status.
+var lastGitHubRateLimitRemaining string
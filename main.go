@@ -2,6 +2,7 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
@@ -10,17 +11,79 @@ import (
 	"net/http"
 	"os"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/joho/godotenv"
+	"github.com/prkpwm/fitness-tracker-backend/retry"
 	"github.com/rs/cors"
 )
 
-var fitnessRecords []FitnessData
+// fitnessRecords is shared across HTTP handlers, the GitHub sync loop,
+// and the Fitbit webhook's per-notification goroutines, so every access
+// to it (and to maps nested inside its records, like
+// ExerciseSummary.BurnedCaloriesBySource) must go through fitnessMu.
+var (
+	fitnessMu      sync.Mutex
+	fitnessRecords []FitnessData
+)
+
 const dataDir = "fitness_data"
 const backupFile = "backup.txt"
 
+// snapshotFitnessRecords returns a copy of fitnessRecords safe to read
+// or range over without holding fitnessMu.
+func snapshotFitnessRecords() []FitnessData {
+	fitnessMu.Lock()
+	defer fitnessMu.Unlock()
+	out := make([]FitnessData, len(fitnessRecords))
+	copy(out, fitnessRecords)
+	return out
+}
+
+// setFitnessRecords replaces fitnessRecords wholesale, e.g. after a
+// GitHub resync.
+func setFitnessRecords(records []FitnessData) {
+	fitnessMu.Lock()
+	defer fitnessMu.Unlock()
+	fitnessRecords = records
+}
+
+// upsertFitnessRecord replaces date's record with data, or appends it if
+// no record for that date exists yet.
+func upsertFitnessRecord(data FitnessData) {
+	fitnessMu.Lock()
+	defer fitnessMu.Unlock()
+	for i, record := range fitnessRecords {
+		if record.Date == data.Date {
+			fitnessRecords[i] = data
+			return
+		}
+	}
+	fitnessRecords = append(fitnessRecords, data)
+}
+
+// mutateFitnessRecord locates date's record (or starts from a zero one),
+// applies mutate to it under fitnessMu, stores the result back, and
+// returns it. mutate must not itself call back into fitnessRecords
+// accessors, since fitnessMu isn't reentrant.
+func mutateFitnessRecord(date string, mutate func(FitnessData) FitnessData) FitnessData {
+	fitnessMu.Lock()
+	defer fitnessMu.Unlock()
+
+	for i, r := range fitnessRecords {
+		if r.Date == date {
+			fitnessRecords[i] = mutate(r)
+			return fitnessRecords[i]
+		}
+	}
+
+	record := mutate(FitnessData{Date: date})
+	fitnessRecords = append(fitnessRecords, record)
+	return record
+}
+
 func loggingMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
@@ -65,7 +128,17 @@ func main() {
 	r.HandleFunc("/api/fitness", createFitnessData).Methods("POST")
 	r.HandleFunc("/api/fitness/{date}", getFitnessDataByDate).Methods("GET")
 	r.HandleFunc("/get", getRawJsonByDate).Methods("GET")
-	
+
+	r.HandleFunc("/auth/fitbit/login", fitbitLoginHandler).Methods("GET")
+	r.HandleFunc("/auth/fitbit/grant", fitbitGrantHandler).Methods("GET")
+	r.HandleFunc("/api/fitness/sync/fitbit", fitbitSyncHandler).Methods("POST")
+	r.HandleFunc("/api/webhooks/fitbit", fitbitWebhookVerifyHandler).Methods("GET")
+	r.HandleFunc("/api/webhooks/fitbit", fitbitWebhookHandler).Methods("POST")
+	r.HandleFunc("/api/admin/refresh", adminRefreshHandler).Methods("POST")
+	r.HandleFunc("/api/admin/status", adminStatusHandler).Methods("GET")
+
+	go registerFitbitSubscriptions()
+
 	c := cors.New(cors.Options{
 		AllowedOrigins: []string{"*"},
 		AllowedMethods: []string{"GET", "POST", "PUT", "DELETE"},
@@ -101,35 +174,26 @@ func getFitnessData(w http.ResponseWriter, r *http.Request) {
 }
 
 func getAllFitnessData(w http.ResponseWriter, r *http.Request) {
-	if fitnessRecords == nil || len(fitnessRecords) == 0 {
+	records := snapshotFitnessRecords()
+	if len(records) == 0 {
 		loadData()
+		records = snapshotFitnessRecords()
 	}
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(fitnessRecords)
+	json.NewEncoder(w).Encode(records)
 }
 
 func createFitnessData(w http.ResponseWriter, r *http.Request) {
 	var data FitnessData
 	json.NewDecoder(r.Body).Decode(&data)
-	
+
 	// Set last update timestamp
 	data.LastUpdate = time.Now().Format("2006-01-02 15:04:05")
-	
-	// Check for duplicate by date and replace if exists
-	for i, record := range fitnessRecords {
-		if record.Date == data.Date {
-			fitnessRecords[i] = data
-			saveData()
-			w.Header().Set("Content-Type", "application/json")
-			json.NewEncoder(w).Encode(data)
-			return
-		}
-	}
-	
-	// If no duplicate found, append new record
-	fitnessRecords = append(fitnessRecords, data)
+
+	upsertFitnessRecord(data)
 	saveData()
-	
+	go exportToGoogleFit(data)
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(data)
 }
@@ -164,14 +228,14 @@ func getRawJsonByDate(w http.ResponseWriter, r *http.Request) {
 		date = time.Now().Format("2006-01-02")
 	}
 	
-	for _, record := range fitnessRecords {
+	for _, record := range snapshotFitnessRecords() {
 		if record.Date == date {
 			w.Header().Set("Content-Type", "application/json")
 			json.NewEncoder(w).Encode(record)
 			return
 		}
 	}
-	
+
 	http.NotFound(w, r)
 }
 
@@ -199,18 +263,19 @@ func getFitnessDataByMonth(w http.ResponseWriter, r *http.Request) {
 }
 
 func loadData() {
-	fitnessRecords = []FitnessData{}
-	
+	setFitnessRecords([]FitnessData{})
+
 	// Load data from GitHub
 	loadFromGitHub()
-	
-	log.Printf("Loaded %d records from GitHub", len(fitnessRecords))
+
+	log.Printf("Loaded %d records from GitHub", len(snapshotFitnessRecords()))
 }
 
 func saveData() {
-	log.Printf("Starting saveData for %d records", len(fitnessRecords))
+	records := snapshotFitnessRecords()
+	log.Printf("Starting saveData for %d records", len(records))
 	// Update GitHub with daily files
-	for _, record := range fitnessRecords {
+	for _, record := range records {
 		t, err := time.Parse("2006-01-02", record.Date)
 		if err != nil {
 			log.Printf("Error parsing date %s: %v", record.Date, err)
@@ -226,7 +291,9 @@ func saveData() {
 		githubPath := fmt.Sprintf("fitness_data/%d/%02d/%02d.json", t.Year(), t.Month(), t.Day())
 		log.Printf("Saving record for date %s to %s", record.Date, githubPath)
 		ensureGitHubDirectories(githubPath)
-		updateGitHubFile(githubPath, data)
+		if err := updateGitHubFile(githubPath, data); err != nil {
+			log.Printf("Giving up on %s after retries: %v", githubPath, err)
+		}
 	}
 }
 
@@ -240,209 +307,230 @@ func ensureGitHubDirectories(filePath string) {
 	parts := strings.Split(filePath, "/")
 	for i := 1; i < len(parts)-1; i++ {
 		dirPath := strings.Join(parts[:i+1], "/")
-		if !checkGitHubPathExists(token, dirPath) {
-			createGitHubDirectory(token, dirPath)
+		exists, err := checkGitHubPathExists(token, dirPath)
+		if err != nil {
+			log.Printf("Giving up checking %s after retries: %v", dirPath, err)
+			continue
+		}
+		if !exists {
+			if err := createGitHubDirectory(token, dirPath); err != nil {
+				log.Printf("Giving up creating directory %s after retries: %v", dirPath, err)
+			}
 		}
 	}
 }
 
-func checkGitHubPathExists(token, path string) bool {
+func checkGitHubPathExists(token, path string) (bool, error) {
 	log.Printf("GitHub API Request: GET %s", path)
 	url := fmt.Sprintf("https://api.github.com/repos/prkpwm/fitness-tracker-backend/contents/%s", path)
-	req, _ := http.NewRequest("GET", url, nil)
-	req.Header.Set("Authorization", "token "+token)
-	
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return false
-	}
-	defer resp.Body.Close()
-	return resp.StatusCode == 200
+
+	var exists bool
+	err := retry.Do(context.Background(), func(ctx context.Context) error {
+		req, _ := http.NewRequestWithContext(ctx, "GET", url, nil)
+		req.Header.Set("Authorization", "token "+token)
+
+		client := &http.Client{}
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode == http.StatusNotFound {
+			exists = false
+			return nil
+		}
+		if resp.StatusCode != http.StatusOK {
+			return wrapGitHubError(resp, readGitHubBody(resp))
+		}
+		exists = true
+		return nil
+	})
+	return exists, err
 }
 
-func createGitHubDirectory(token, dirPath string) {
+func createGitHubDirectory(token, dirPath string) error {
 	log.Printf("GitHub API Request: PUT %s/.gitkeep", dirPath)
 	readmePath := fmt.Sprintf("%s/.gitkeep", dirPath)
-	payload := map[string]interface{}{
-		"message": fmt.Sprintf("Create directory %s", dirPath),
-		"content": base64.StdEncoding.EncodeToString([]byte("")),
-	}
-	
-	jsonPayload, _ := json.Marshal(payload)
 	url := fmt.Sprintf("https://api.github.com/repos/prkpwm/fitness-tracker-backend/contents/%s", readmePath)
-	req, _ := http.NewRequest("PUT", url, bytes.NewBuffer(jsonPayload))
-	req.Header.Set("Authorization", "token "+token)
-	req.Header.Set("Content-Type", "application/json")
-	
-	client := &http.Client{}
-	resp, err := client.Do(req)
+
+	err := retry.Do(context.Background(), func(ctx context.Context) error {
+		payload := map[string]interface{}{
+			"message": fmt.Sprintf("Create directory %s", dirPath),
+			"content": base64.StdEncoding.EncodeToString([]byte("")),
+		}
+
+		jsonPayload, _ := json.Marshal(payload)
+		req, _ := http.NewRequestWithContext(ctx, "PUT", url, bytes.NewBuffer(jsonPayload))
+		req.Header.Set("Authorization", "token "+token)
+		req.Header.Set("Content-Type", "application/json")
+
+		client := &http.Client{}
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode == http.StatusCreated {
+			return nil
+		}
+		return wrapGitHubError(resp, readGitHubBody(resp))
+	})
+
 	if err != nil {
-		log.Printf("Failed to create directory %s: %v", dirPath, err)
-		return
-	}
-	defer resp.Body.Close()
-	
-	if resp.StatusCode == 201 {
-		log.Printf("Created directory: %s", dirPath)
+		return err
 	}
+	log.Printf("Created directory: %s", dirPath)
+	return nil
 }
 
-func updateGitHubFile(githubPath string, data []byte) {
+func updateGitHubFile(githubPath string, data []byte) error {
 	token := os.Getenv("UP_TOK")
 	if token == "" {
-		return
+		return nil
 	}
-	
+
 	log.Printf("GitHub API Request: PUT %s", githubPath)
-	
-	sha := getGitHubFileSHA(token, githubPath)
-	
-	payload := map[string]interface{}{
-		"message": fmt.Sprintf("Update %s", githubPath),
-		"content": base64.StdEncoding.EncodeToString(data),
-	}
-	
-	if sha != "" {
-		payload["sha"] = sha
+
+	sha, err := getGitHubFileSHA(token, githubPath)
+	if err != nil {
+		log.Printf("GitHub API failed to fetch SHA for %s: %v", githubPath, err)
+		return err
 	}
-	
-	jsonPayload, _ := json.Marshal(payload)
+
 	url := fmt.Sprintf("https://api.github.com/repos/prkpwm/fitness-tracker-backend/contents/%s", githubPath)
-	req, _ := http.NewRequest("PUT", url, bytes.NewBuffer(jsonPayload))
-	req.Header.Set("Authorization", "token "+token)
-	req.Header.Set("Content-Type", "application/json")
-	
-	client := &http.Client{}
-	resp, err := client.Do(req)
+
+	err = retry.Do(context.Background(), func(ctx context.Context) error {
+		payload := map[string]interface{}{
+			"message": fmt.Sprintf("Update %s", githubPath),
+			"content": base64.StdEncoding.EncodeToString(data),
+		}
+		if sha != "" {
+			payload["sha"] = sha
+		}
+
+		jsonPayload, _ := json.Marshal(payload)
+		req, _ := http.NewRequestWithContext(ctx, "PUT", url, bytes.NewBuffer(jsonPayload))
+		req.Header.Set("Authorization", "token "+token)
+		req.Header.Set("Content-Type", "application/json")
+
+		client := &http.Client{}
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode == http.StatusOK || resp.StatusCode == http.StatusCreated {
+			return nil
+		}
+
+		body := readGitHubBody(resp)
+		if resp.StatusCode == http.StatusConflict {
+			log.Printf("GitHub API conflict on %s, refetching SHA", githubPath)
+			newSHA, shaErr := getGitHubFileSHA(token, githubPath)
+			if shaErr != nil {
+				return shaErr
+			}
+			sha = newSHA
+		}
+		return wrapGitHubError(resp, body)
+	})
+
 	if err != nil {
-		log.Printf("GitHub API Error: %v", err)
-		return
-	}
-	defer resp.Body.Close()
-	
-	if resp.StatusCode == 200 || resp.StatusCode == 201 {
-		log.Printf("Updated GitHub: %s", githubPath)
-	} else {
-		log.Printf("GitHub API failed: %d", resp.StatusCode)
+		log.Printf("GitHub API failed for %s: %v", githubPath, err)
+		return err
 	}
+	log.Printf("Updated GitHub: %s", githubPath)
+	return nil
 }
 
-func getGitHubFileSHA(token, filePath string) string {
+func getGitHubFileSHA(token, filePath string) (string, error) {
 	url := fmt.Sprintf("https://api.github.com/repos/prkpwm/fitness-tracker-backend/contents/%s", filePath)
-	req, _ := http.NewRequest("GET", url, nil)
-	req.Header.Set("Authorization", "token "+token)
-	
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return ""
-	}
-	defer resp.Body.Close()
-	
-	var result map[string]interface{}
-	json.NewDecoder(resp.Body).Decode(&result)
-	
-	if sha, ok := result["sha"].(string); ok {
-		return sha
-	}
-	return ""
+
+	var sha string
+	err := retry.Do(context.Background(), func(ctx context.Context) error {
+		req, _ := http.NewRequestWithContext(ctx, "GET", url, nil)
+		req.Header.Set("Authorization", "token "+token)
+
+		client := &http.Client{}
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode == http.StatusNotFound {
+			sha = ""
+			return nil
+		}
+		if resp.StatusCode != http.StatusOK {
+			return wrapGitHubError(resp, readGitHubBody(resp))
+		}
+
+		var result map[string]interface{}
+		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+			return err
+		}
+		sha, _ = result["sha"].(string)
+		return nil
+	})
+	return sha, err
 }
 
+// loadFromGitHub refreshes fitnessRecords from the fitness_data tree,
+// reusing the on-disk cache and only re-downloading files whose blob SHA
+// changed since the last sync. See refreshFromGitHub.
 func loadFromGitHub() {
-	start := time.Now()
-	log.Printf("[%s] Starting full GitHub data load", start.Format("15:04:05"))
-	token := os.Getenv("UP_TOK")
-	if token == "" {
-		log.Printf("[%s] No GitHub token available", time.Now().Format("15:04:05"))
-		return
+	if err := refreshFromGitHub(false); err != nil {
+		log.Printf("Full GitHub data load failed: %v", err)
 	}
-	
-	// Get fitness_data directory contents
-	years := getGitHubDirectoryContents(token, "fitness_data")
-	log.Printf("[%s] Found %d years in GitHub", time.Now().Format("15:04:05"), len(years))
-	
-	for _, year := range years {
-		// Get year directory contents
-		months := getGitHubDirectoryContents(token, fmt.Sprintf("fitness_data/%s", year))
-		log.Printf("[%s] Found %d months in year %s", time.Now().Format("15:04:05"), len(months), year)
-		
-		for _, month := range months {
-			// Get month directory contents (daily files)
-			days := getGitHubDirectoryContents(token, fmt.Sprintf("fitness_data/%s/%s", year, month))
-			log.Printf("[%s] Found %d days in %s/%s", time.Now().Format("15:04:05"), len(days), year, month)
-			
-			for _, day := range days {
-				if !strings.HasSuffix(day, ".json") {
-					continue
-				}
-				
-				githubPath := fmt.Sprintf("fitness_data/%s/%s/%s", year, month, day)
-				url := fmt.Sprintf("https://raw.githubusercontent.com/prkpwm/fitness-tracker-backend/main/%s", githubPath)
-				
-				resp, err := http.Get(url)
-				if err != nil || resp.StatusCode != 200 {
-					log.Printf("[%s] Failed to load %s (status: %d)", time.Now().Format("15:04:05"), githubPath, resp.StatusCode)
-					if resp != nil {
-						resp.Body.Close()
-					}
-					continue
-				}
-				
-				data, err := io.ReadAll(resp.Body)
-				resp.Body.Close()
-				if err != nil {
-					log.Printf("[%s] Error reading %s: %v", time.Now().Format("15:04:05"), githubPath, err)
-					continue
-				}
-				
-				var dailyRecords []FitnessData
-				err = json.Unmarshal(data, &dailyRecords)
-				if err != nil {
-					log.Printf("[%s] Error parsing %s: %v", time.Now().Format("15:04:05"), githubPath, err)
-					continue
-				}
-				
-				log.Printf("[%s] Loaded %d records from %s", time.Now().Format("15:04:05"), len(dailyRecords), githubPath)
-				fitnessRecords = append(fitnessRecords, dailyRecords...)
-			}
-		}
-	}
-	duration := time.Since(start)
-	log.Printf("[%s] Completed full GitHub data load: %d total records (took %v)", time.Now().Format("15:04:05"), len(fitnessRecords), duration)
 }
 
-func getGitHubDirectoryContents(token, path string) []string {
+func getGitHubDirectoryContents(token, path string) ([]string, error) {
 	start := time.Now()
 	log.Printf("[%s] Getting GitHub directory contents: %s", start.Format("15:04:05"), path)
 	url := fmt.Sprintf("https://api.github.com/repos/prkpwm/fitness-tracker-backend/contents/%s", path)
-	req, _ := http.NewRequest("GET", url, nil)
-	req.Header.Set("Authorization", "token "+token)
-	
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil || resp.StatusCode != 200 {
-		log.Printf("[%s] Failed to get directory contents: %s (status: %d)", time.Now().Format("15:04:05"), path, resp.StatusCode)
-		if resp != nil {
-			resp.Body.Close()
-		}
-		return nil
-	}
-	defer resp.Body.Close()
-	
-	var contents []map[string]interface{}
-	json.NewDecoder(resp.Body).Decode(&contents)
-	
+
 	var names []string
-	for _, item := range contents {
-		if name, ok := item["name"].(string); ok {
-			names = append(names, name)
+	err := retry.Do(context.Background(), func(ctx context.Context) error {
+		req, _ := http.NewRequestWithContext(ctx, "GET", url, nil)
+		req.Header.Set("Authorization", "token "+token)
+
+		client := &http.Client{}
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			return wrapGitHubError(resp, readGitHubBody(resp))
 		}
+
+		var contents []map[string]interface{}
+		if err := json.NewDecoder(resp.Body).Decode(&contents); err != nil {
+			return err
+		}
+
+		names = nil
+		for _, item := range contents {
+			if name, ok := item["name"].(string); ok {
+				names = append(names, name)
+			}
+		}
+		return nil
+	})
+
+	if err != nil {
+		log.Printf("[%s] Failed to get directory contents: %s: %v", time.Now().Format("15:04:05"), path, err)
+		return nil, err
 	}
-	
+
 	duration := time.Since(start)
 	log.Printf("[%s] Found %d items in %s (took %v)", time.Now().Format("15:04:05"), len(names), path, duration)
-	return names
+	return names, nil
 }
 
 func loadFromGitHubByPath(path string) []FitnessData {
@@ -455,10 +543,14 @@ func loadFromGitHubByPath(path string) []FitnessData {
 	}
 	
 	var records []FitnessData
-	
+
 	// Get directory contents (daily files)
-	files := getGitHubDirectoryContents(token, path)
-	
+	files, err := getGitHubDirectoryContents(token, path)
+	if err != nil {
+		log.Printf("[%s] Giving up listing %s after retries: %v", time.Now().Format("15:04:05"), path, err)
+		return nil
+	}
+
 	for _, file := range files {
 		if !strings.HasSuffix(file, ".json") {
 			continue
@@ -544,7 +636,11 @@ func loadFromGitHubByYearPath(yearPath string) []FitnessData {
 	var records []FitnessData
 
 	// Get month directories
-	months := getGitHubDirectoryContents(token, yearPath)
+	months, err := getGitHubDirectoryContents(token, yearPath)
+	if err != nil {
+		log.Printf("[%s] Giving up listing %s after retries: %v", time.Now().Format("15:04:05"), yearPath, err)
+		return nil
+	}
 	log.Printf("[%s] Found %d months in %s", time.Now().Format("15:04:05"), len(months), yearPath)
 
 	for _, month := range months {
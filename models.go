@@ -21,9 +21,24 @@ type FoodItem struct {
 	FatG     float64 `json:"fat_g"`
 }
 
+type ExerciseEntry struct {
+	Name     string `json:"name"`
+	Calories int    `json:"calories"`
+	Minutes  int    `json:"minutes"`
+	// Source identifies what contributed this entry (e.g.
+	// "fitbit:<user_id>"), so one contributor's sync can replace its own
+	// entries without clobbering another contributor's for the same day.
+	Source string `json:"source,omitempty"`
+}
+
 type ExerciseSummary struct {
-	TotalBurnedCalories int    `json:"total_burned_calories"`
-	Status              string `json:"status,omitempty"`
+	TotalBurnedCalories int             `json:"total_burned_calories"`
+	Status              string          `json:"status,omitempty"`
+	Workouts            []ExerciseEntry `json:"workouts,omitempty"`
+	// BurnedCaloriesBySource tracks each contributor's total burned
+	// calories so TotalBurnedCalories can be recomputed as their sum
+	// instead of one contributor overwriting another's number.
+	BurnedCaloriesBySource map[string]int `json:"burned_calories_by_source,omitempty"`
 }
 
 type DailyTotalStats struct {
@@ -49,4 +64,5 @@ type FitnessData struct {
 	ExerciseSummary ExerciseSummary  `json:"exercise_summary"`
 	DailyTotalStats DailyTotalStats  `json:"daily_total_stats"`
 	AIEvaluation    AIEvaluation     `json:"ai_evaluation"`
+	LastUpdate      string           `json:"last_update,omitempty"`
 }
\ No newline at end of file
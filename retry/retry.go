@@ -0,0 +1,73 @@
+// Package retry provides a small exponential-backoff retry loop shared
+// by every outbound API call (GitHub, Fitbit, Google Fit) so transient
+// failures don't silently drop writes.
+package retry
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"time"
+)
+
+const (
+	initialBackoff = 200 * time.Millisecond
+	maxBackoff     = 30 * time.Second
+	maxAttempts    = 8
+)
+
+// abortError marks an error as permanent: Do returns it immediately
+// instead of retrying.
+type abortError struct {
+	err error
+}
+
+func (a *abortError) Error() string { return a.err.Error() }
+func (a *abortError) Unwrap() error { return a.err }
+
+// Abort wraps err so that Do treats it as permanent and stops retrying.
+func Abort(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &abortError{err: err}
+}
+
+// Do runs fn, retrying with exponential backoff and jitter on any error
+// that isn't wrapped with Abort. It gives up after maxAttempts or when
+// ctx is cancelled, whichever comes first.
+func Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	backoff := initialBackoff
+
+	var lastErr error
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		err := fn(ctx)
+		if err == nil {
+			return nil
+		}
+
+		var ab *abortError
+		if errors.As(err, &ab) {
+			return ab.err
+		}
+		lastErr = err
+
+		if attempt == maxAttempts {
+			break
+		}
+
+		sleep := backoff/2 + time.Duration(rand.Int63n(int64(backoff/2+1)))
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(sleep):
+		}
+
+		backoff *= 2
+		if backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+
+	return lastErr
+}